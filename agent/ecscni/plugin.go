@@ -16,6 +16,8 @@ package ecscni
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -30,25 +32,52 @@ import (
 
 const (
 	currentCNISpec = "0.3.1"
+	// cniSpec040 is the CNI spec version that introduced the CHECK verb and the prevResult convention on DEL/CHECK.
+	cniSpec040 = "0.4.0"
 	// ECSCNIVersion, ECSCNIGitHash, VPCCNIGitHash needs to be updated every time CNI plugin is updated
 	currentECSCNIVersion      = "2020.09.0"
 	currentECSCNIGitHash      = "55b2ae77ee0bf22321b14f2d4ebbcc04f77322e1"
 	currentVPCCNIGitHash      = "a21d3a41f922e14c19387713df66be3e4ee1e1f6"
 	vpcCNIPluginInterfaceType = "vlan"
+
+	// cniCacheDir is where setupNS persists the CNI result and the exact network/runtime configuration used for
+	// each attachment, keyed by container ID and interface name. cleanupNS and checkNS prefer this cached state
+	// over the live configuration passed in by the caller, so task networking can be recovered across an agent
+	// restart, mirroring the result-cache model libcni itself added in 0.8.x.
+	cniCacheDir = "/var/lib/ecs/data/cni-cache"
+
+	// Capability names recognized on RuntimeConfig, matching the capability args the corresponding upstream CNI
+	// plugins (ptp/bridge, static, bandwidth) advertise and expect.
+	capabilityMAC       = "mac"
+	capabilityIPs       = "ips"
+	capabilityBandwidth = "bandwidth"
+	capabilityAliases   = "aliases"
 )
 
+// supportedCNISpecs is the set of CNI spec versions the agent knows how to speak, in order of preference. When
+// negotiating with a plugin, the highest entry here that the plugin also advertises support for is selected.
+var supportedCNISpecs = []string{cniSpec040, currentCNISpec}
+
+//go:generate mockgen -destination=mocks/ecscni_mocks.go -copyright_file=../../scripts/copyright_file -package=mock_ecscni github.com/aws/amazon-ecs-agent/agent/ecscni CNIClient
+
 // CNIClient defines the method of setting/cleaning up container namespace
 type CNIClient interface {
 	// Version returns the version of the plugin
 	Version(string) (string, error)
 	// Capabilities returns the capabilities supported by a plugin
 	Capabilities(string) ([]string, error)
-	// SetupNS sets up the namespace of container
-	SetupNS(context.Context, *Config, time.Duration) (*current.Result, error)
+	// SetupNS sets up the namespace of container, returning the result of every configured attachment
+	SetupNS(context.Context, *Config, time.Duration) (AttachmentResults, error)
 	// CleanupNS cleans up the container namespace
 	CleanupNS(context.Context, *Config, time.Duration) error
+	// CheckNS checks that the container namespace still matches the given CNI configuration
+	CheckNS(context.Context, *Config, time.Duration) error
 	// ReleaseIPResource marks the ip available in the ipam db
 	ReleaseIPResource(context.Context, *Config, time.Duration) error
+	// GC reconciles the on-disk cni result cache against known, the set of tasks the agent currently believes
+	// are running, tearing down any cached attachment whose container isn't in known. It's meant to be called
+	// at agent startup to clean up networking state left behind by a crash mid-CleanupNS.
+	GC(context.Context, []Config) error
 }
 
 // cniClient is the client to call plugin and setup the network
@@ -78,13 +107,13 @@ func (client *cniClient) init() {
 	os.Setenv("VPC_CNI_LOG_FILE", vpcCNIPluginPath)
 }
 
-// SetupNS sets up the network namespace of a task by invoking the given CNI network configurations.
-// It returns the result of the bridge plugin invocation as that result is used to parse the IPv4
-// address allocated to the veth device attached to the task by the task engine.
+// SetupNS sets up the network namespace of a task by invoking the given CNI network configurations. It
+// returns the result of every configured logical network (attachment), keyed by attachment name, so callers
+// no longer have to guess which entry of cfg.NetworkConfigs produced the "interesting" result.
 func (client *cniClient) SetupNS(
 	ctx context.Context,
 	cfg *Config,
-	timeout time.Duration) (*current.Result, error) {
+	timeout time.Duration) (AttachmentResults, error) {
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 	return client.setupNS(ctx, cfg)
@@ -103,54 +132,432 @@ func (client *cniClient) CleanupNS(
 	return client.cleanupNS(ctx, cfg)
 }
 
-// cleanupNS is called by CleanupNS to cleanup the task namespace by invoking DEL for given CNI configurations
+// cleanupNS is called by CleanupNS to cleanup the task namespace by invoking DEL for given CNI configurations.
+// For each attachment, the cached network/runtime configuration from setupNS is preferred over the live
+// cfg.NetworkConfigs, so a namespace can still be torn down correctly after an agent restart.
 func (client *cniClient) cleanupNS(ctx context.Context, cfg *Config) error {
 	seelog.Debugf("[ECSCNI] Cleaning up the container namespace %s", cfg.ContainerID)
 
+	// Execute all CNI network configurations serially, in the reverse order.
+	for i := len(cfg.NetworkConfigs) - 1; i >= 0; i-- {
+		networkConfig := cfg.NetworkConfigs[i]
+		cniNetworkConfigList := networkConfig.CNINetworkConfig
+		// runtimeConfig is declared fresh each iteration: when no cache entry is found below, it must not carry
+		// over the previous attachment's CapabilityArgs/NetNS from a cached entry.
+		runtimeConfig := libcni.RuntimeConf{
+			ContainerID: cfg.ContainerID,
+			NetNS:       cfg.ContainerNetNS,
+			IfName:      networkConfig.IfName,
+		}
+
+		if cached, err := readCachedNetworkConfig(cfg.ContainerID, networkConfig.IfName); err != nil {
+			seelog.Warnf("[ECSCNI] Unable to read cni cache entry for %s %s, falling back to live config: %v",
+				cfg.ContainerID, networkConfig.IfName, err)
+		} else if cached != nil {
+			cniNetworkConfigList = cached.NetworkConfig
+			runtimeConfig = *cached.RuntimeConfig
+
+			if cached.NetworkConfig.CNIVersion == cniSpec040 && cached.Result != nil {
+				withResult, err := withPrevResultList(cniNetworkConfigList, cached.Result)
+				if err != nil {
+					seelog.Warnf("[ECSCNI] Unable to set prevResult for %s %s: %v",
+						cfg.ContainerID, networkConfig.IfName, err)
+				} else {
+					cniNetworkConfigList = withResult
+				}
+			}
+		}
+
+		seelog.Debugf("[ECSCNI] Deleting network %s in the container namespace %s",
+			cniNetworkConfigList.Name, cfg.ContainerID)
+		err := client.libcni.DelNetworkList(ctx, cniNetworkConfigList, &runtimeConfig)
+		if err != nil {
+			return errors.Wrap(err, "delete network failed")
+		}
+
+		if err := removeCachedNetworkConfig(cfg.ContainerID, networkConfig.IfName); err != nil {
+			seelog.Warnf("[ECSCNI] Unable to remove cni cache entry for %s %s: %v",
+				cfg.ContainerID, networkConfig.IfName, err)
+		}
+
+		seelog.Debugf("[ECSCNI] Completed deleting network %s in the container namespace %s",
+			cniNetworkConfigList.Name, cfg.ContainerID)
+	}
+
+	seelog.Debugf("[ECSCNI] Completed cleaning up the container namespace %s", cfg.ContainerID)
+
+	return nil
+}
+
+// setupNS is called by SetupNS to configure the task namespace by invoking ADD, via a single AddNetworkList call,
+// for each configured logical network in order. The network/runtime configuration and result of each ADD is
+// cached to disk so that cleanupNS and checkNS can recover it later, even across an agent restart. Each
+// attachment's outcome, including any error, is recorded in the returned AttachmentResults; a non-nil error is
+// also returned if any attachment failed, so callers that don't need partial results can treat SetupNS as
+// all-or-nothing.
+func (client *cniClient) setupNS(ctx context.Context, cfg *Config) (AttachmentResults, error) {
+	seelog.Debugf("[ECSCNI] Setting up the container namespace %s", cfg.ContainerID)
+
 	runtimeConfig := libcni.RuntimeConf{
 		ContainerID: cfg.ContainerID,
 		NetNS:       cfg.ContainerNetNS,
 	}
 
-	// Execute all CNI network configurations serially, in the reverse order.
-	for i := len(cfg.NetworkConfigs) - 1; i >= 0; i-- {
-		networkConfig := cfg.NetworkConfigs[i]
-		cniNetworkConfig := networkConfig.CNINetworkConfig
-		seelog.Debugf("[ECSCNI] Deleting network %s type %s in the container namespace %s",
-			cniNetworkConfig.Network.Name,
-			cniNetworkConfig.Network.Type,
-			cfg.ContainerID)
+	// AttachmentResults is keyed by IfName rather than Name: IfName is what libcni.RuntimeConf.IfName is set to
+	// for each attachment and so is guaranteed unique within a single setupNS call, whereas Name is caller-supplied
+	// and isn't validated elsewhere. Fail fast here rather than silently overwriting one attachment's result with
+	// another's.
+	seenIfNames := make(map[string]struct{}, len(cfg.NetworkConfigs))
+	for _, networkConfig := range cfg.NetworkConfigs {
+		if networkConfig.IfName == "" {
+			return nil, errors.Errorf("setup namespace %s: network %s has an empty IfName", cfg.ContainerID, networkConfig.Name)
+		}
+		if _, ok := seenIfNames[networkConfig.IfName]; ok {
+			return nil, errors.Errorf("setup namespace %s: duplicate IfName %s", cfg.ContainerID, networkConfig.IfName)
+		}
+		seenIfNames[networkConfig.IfName] = struct{}{}
+	}
+
+	results := AttachmentResults{}
+	var lastErr error
+	for _, networkConfig := range cfg.NetworkConfigs {
+		cniNetworkConfigList := networkConfig.CNINetworkConfig
+
+		negotiatedVersion, err := client.negotiateListVersion(cniNetworkConfigList)
+		if err != nil {
+			seelog.Warnf("[ECSCNI] Unable to negotiate cni spec version for network %s, falling back to %s: %v",
+				networkConfig.Name, currentCNISpec, err)
+		}
+
+		if negotiatedVersion != cniNetworkConfigList.CNIVersion {
+			stamped, err := stampCNIVersionList(cniNetworkConfigList, negotiatedVersion)
+			if err != nil {
+				results[networkConfig.IfName] = &AttachmentResult{
+					Name: networkConfig.Name, IfName: networkConfig.IfName, Error: err,
+				}
+				lastErr = err
+				continue
+			}
+			cniNetworkConfigList = stamped
+		}
+
+		seelog.Debugf("[ECSCNI] Adding network %s using cni spec %s in the container namespace %s",
+			cniNetworkConfigList.Name, negotiatedVersion, cfg.ContainerID)
 		runtimeConfig.IfName = networkConfig.IfName
-		err := client.libcni.DelNetwork(ctx, cniNetworkConfig, &runtimeConfig)
+		runtimeConfig.CapabilityArgs = client.buildCapabilityArgs(cniNetworkConfigList, networkConfig.RuntimeConfig)
+
+		addResult, err := client.libcni.AddNetworkList(ctx, cniNetworkConfigList, &runtimeConfig)
 		if err != nil {
-			return errors.Wrap(err, "delete network failed")
+			err = errors.Wrapf(err, "add network %s failed", networkConfig.Name)
+			results[networkConfig.IfName] = &AttachmentResult{
+				Name: networkConfig.Name, IfName: networkConfig.IfName, CNIVersion: negotiatedVersion, Error: err,
+			}
+			lastErr = err
+			continue
 		}
 
-		seelog.Debugf("[ECSCNI] Completed deleting network %s type %s in the container namespace %s",
-			cniNetworkConfig.Network.Name,
-			cniNetworkConfig.Network.Type,
-			cfg.ContainerID)
+		result, err := current.NewResultFromResult(addResult)
+		if err != nil {
+			err = errors.Wrapf(err, "unable to parse add network %s result", networkConfig.Name)
+			results[networkConfig.IfName] = &AttachmentResult{
+				Name: networkConfig.Name, IfName: networkConfig.IfName, CNIVersion: negotiatedVersion, Error: err,
+			}
+			lastErr = err
+			continue
+		}
+
+		if err := cacheNetworkConfig(cfg.ContainerID, networkConfig.IfName, cniNetworkConfigList, &runtimeConfig, result); err != nil {
+			seelog.Warnf("[ECSCNI] Unable to cache cni result for %s %s: %v", cfg.ContainerID, networkConfig.IfName, err)
+		}
+
+		results[networkConfig.IfName] = &AttachmentResult{
+			Name:       networkConfig.Name,
+			IfName:     networkConfig.IfName,
+			CNIVersion: negotiatedVersion,
+			Result:     result,
+		}
+
+		seelog.Debugf("[ECSCNI] Completed adding network %s in the container namespace %s",
+			cniNetworkConfigList.Name, cfg.ContainerID)
 	}
 
-	seelog.Debugf("[ECSCNI] Completed cleaning up the container namespace %s", cfg.ContainerID)
+	seelog.Debugf("[ECSCNI] Completed setting up the container namespace %s", cfg.ContainerID)
+	return results, lastErr
+}
+
+// CheckNS checks that the task's network namespace still matches the given CNI configuration, by invoking the CNI
+// CHECK verb for every configured network.
+func (client *cniClient) CheckNS(
+	ctx context.Context,
+	cfg *Config,
+	timeout time.Duration) error {
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return client.checkNS(ctx, cfg)
+}
+
+// checkNS is called by CheckNS to verify the task namespace by invoking CHECK for given CNI configurations. As with
+// cleanupNS, the cached network/runtime configuration from setupNS is preferred over the live cfg.NetworkConfigs
+// when present.
+func (client *cniClient) checkNS(ctx context.Context, cfg *Config) error {
+	seelog.Debugf("[ECSCNI] Checking the container namespace %s", cfg.ContainerID)
+
+	for _, networkConfig := range cfg.NetworkConfigs {
+		cniNetworkConfigList := networkConfig.CNINetworkConfig
+		// runtimeConfig is declared fresh each iteration: when no cache entry is found below, it must not carry
+		// over the previous attachment's CapabilityArgs/NetNS from a cached entry.
+		runtimeConfig := libcni.RuntimeConf{
+			ContainerID: cfg.ContainerID,
+			NetNS:       cfg.ContainerNetNS,
+			IfName:      networkConfig.IfName,
+		}
+
+		if cached, err := readCachedNetworkConfig(cfg.ContainerID, networkConfig.IfName); err != nil {
+			seelog.Warnf("[ECSCNI] Unable to read cni cache entry for %s %s, falling back to live config: %v",
+				cfg.ContainerID, networkConfig.IfName, err)
+		} else if cached != nil {
+			cniNetworkConfigList = cached.NetworkConfig
+			runtimeConfig = *cached.RuntimeConfig
+
+			if cached.NetworkConfig.CNIVersion == cniSpec040 && cached.Result != nil {
+				withResult, err := withPrevResultList(cniNetworkConfigList, cached.Result)
+				if err != nil {
+					seelog.Warnf("[ECSCNI] Unable to set prevResult for %s %s: %v",
+						cfg.ContainerID, networkConfig.IfName, err)
+				} else {
+					cniNetworkConfigList = withResult
+				}
+			}
+		}
+
+		if err := client.libcni.CheckNetworkList(ctx, cniNetworkConfigList, &runtimeConfig); err != nil {
+			return errors.Wrapf(err, "check network %s failed in the container namespace %s",
+				cniNetworkConfigList.Name, cfg.ContainerID)
+		}
+	}
+
+	seelog.Debugf("[ECSCNI] Completed checking the container namespace %s", cfg.ContainerID)
+	return nil
+}
+
+// GC performs a startup reconciliation pass against the on-disk cni result cache: every cached attachment whose
+// ContainerID isn't in known is considered stale and torn down with a DEL, the same way cleanupNS tears down a
+// live attachment. libcni.CNI.GCNetworkList and its ValidAttachments-based reconciliation only exist from
+// libcni 1.1.0 onward, a version that dropped the types/current package this client is built against, so GC
+// can't use it here and falls back to a DEL per stale attachment instead.
+func (client *cniClient) GC(ctx context.Context, known []Config) error {
+	knownContainerIDs := map[string]bool{}
+	for _, cfg := range known {
+		knownContainerIDs[cfg.ContainerID] = true
+	}
+
+	entries, err := ioutil.ReadDir(cniCacheDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrap(err, "unable to list cni cache directory")
+	}
+
+	var stale []*networkConfigCache
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(cniCacheDir, entry.Name())
+		cached, err := readCachedNetworkConfigFile(path)
+		if err != nil {
+			seelog.Warnf("[ECSCNI] Unable to read cni cache entry %s during gc: %v", path, err)
+			continue
+		}
+
+		if knownContainerIDs[cached.RuntimeConfig.ContainerID] {
+			continue
+		}
+
+		stale = append(stale, cached)
+	}
+
+	return client.gcDeleteStale(ctx, stale)
+}
+
+// gcDeleteStale tears down each stale attachment with a DEL, the same way cleanupNS does, then removes its cni
+// cache entry.
+func (client *cniClient) gcDeleteStale(ctx context.Context, stale []*networkConfigCache) error {
+	var lastErr error
+	for _, cached := range stale {
+		networkConfig := cached.NetworkConfig
+		runtimeConfig := *cached.RuntimeConfig
+
+		if networkConfig.CNIVersion == cniSpec040 && cached.Result != nil {
+			if withResult, err := withPrevResultList(networkConfig, cached.Result); err != nil {
+				seelog.Warnf("[ECSCNI] Unable to set prevResult for %s %s during gc: %v",
+					runtimeConfig.ContainerID, runtimeConfig.IfName, err)
+			} else {
+				networkConfig = withResult
+			}
+		}
+
+		seelog.Infof("[ECSCNI] Garbage collecting stale network %s for container %s",
+			networkConfig.Name, runtimeConfig.ContainerID)
+		if err := client.libcni.DelNetworkList(ctx, networkConfig, &runtimeConfig); err != nil {
+			seelog.Warnf("[ECSCNI] Unable to gc stale network %s for container %s: %v",
+				networkConfig.Name, runtimeConfig.ContainerID, err)
+			lastErr = err
+			continue
+		}
 
+		if err := removeCachedNetworkConfig(runtimeConfig.ContainerID, runtimeConfig.IfName); err != nil {
+			seelog.Warnf("[ECSCNI] Unable to remove cni cache entry for %s %s during gc: %v",
+				runtimeConfig.ContainerID, runtimeConfig.IfName, err)
+		}
+	}
+	return lastErr
+}
+
+// stampCNIVersionList returns a copy of list with its top-level cniVersion field set to version, re-parsing the
+// underlying raw configuration so that libcni.NetworkConfigList's parsed fields and .Bytes stay in sync.
+func stampCNIVersionList(list *libcni.NetworkConfigList, version string) (*libcni.NetworkConfigList, error) {
+	raw := map[string]interface{}{}
+	if err := json.Unmarshal(list.Bytes, &raw); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal cni network config list")
+	}
+
+	raw["cniVersion"] = version
+
+	bytes, err := json.Marshal(raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal cni network config list")
+	}
+
+	return libcni.ConfListFromBytes(bytes)
+}
+
+// withPrevResultList returns a copy of list with prevResult set to result, as required by CNI spec 0.4.0 when
+// invoking DEL or CHECK against a logical network that was previously set up with ADD.
+func withPrevResultList(list *libcni.NetworkConfigList, result *current.Result) (*libcni.NetworkConfigList, error) {
+	raw := map[string]interface{}{}
+	if err := json.Unmarshal(list.Bytes, &raw); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal cni network config list")
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal prevResult")
+	}
+
+	prevResult := map[string]interface{}{}
+	if err := json.Unmarshal(resultBytes, &prevResult); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal prevResult")
+	}
+
+	raw["prevResult"] = prevResult
+
+	bytes, err := json.Marshal(raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal cni network config list")
+	}
+
+	return libcni.ConfListFromBytes(bytes)
+}
+
+// networkConfigCache is the on-disk representation of a single attachment's CNI state: the exact network and
+// runtime configuration used for its ADD, plus the result ADD returned.
+type networkConfigCache struct {
+	NetworkConfig *libcni.NetworkConfigList `json:"networkConfig"`
+	RuntimeConfig *libcni.RuntimeConf       `json:"runtimeConfig"`
+	Result        *current.Result           `json:"result"`
+}
+
+// cniCacheFilePath returns the path of the on-disk cache file for a given container/interface attachment.
+func cniCacheFilePath(containerID, ifName string) string {
+	return filepath.Join(cniCacheDir, fmt.Sprintf("%s-%s.json", containerID, ifName))
+}
+
+// cacheNetworkConfig persists the network config, runtime config, and result of an attachment's ADD, so it can be
+// recovered by cleanupNS/checkNS after an agent restart.
+func cacheNetworkConfig(containerID, ifName string, networkConfig *libcni.NetworkConfigList,
+	runtimeConfig *libcni.RuntimeConf, result *current.Result) error {
+	if err := os.MkdirAll(cniCacheDir, os.ModePerm); err != nil {
+		return errors.Wrap(err, "unable to create cni cache directory")
+	}
+
+	data, err := json.Marshal(&networkConfigCache{
+		NetworkConfig: networkConfig,
+		RuntimeConfig: runtimeConfig,
+		Result:        result,
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal cni cache entry")
+	}
+
+	return ioutil.WriteFile(cniCacheFilePath(containerID, ifName), data, os.FileMode(0644))
+}
+
+// readCachedNetworkConfig loads a previously persisted attachment's cni cache entry, if one exists. It returns
+// nil, nil if there is no cache entry for the given container/interface.
+func readCachedNetworkConfig(containerID, ifName string) (*networkConfigCache, error) {
+	cache, err := readCachedNetworkConfigFile(cniCacheFilePath(containerID, ifName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "unable to read cni cache entry")
+	}
+	return cache, nil
+}
+
+// readCachedNetworkConfigFile loads a cni cache entry from the given path.
+func readCachedNetworkConfigFile(path string) (*networkConfigCache, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := &networkConfigCache{}
+	if err := json.Unmarshal(data, cache); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal cni cache entry")
+	}
+	return cache, nil
+}
+
+// removeCachedNetworkConfig deletes a persisted cni cache entry, if one exists.
+func removeCachedNetworkConfig(containerID, ifName string) error {
+	err := os.Remove(cniCacheFilePath(containerID, ifName))
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "unable to remove cni cache entry")
+	}
 	return nil
 }
 
 // Version returns the version of the plugin
 func (client *cniClient) Version(name string) (string, error) {
+	version, err := client.pluginVersion(name)
+	if err != nil {
+		return "", err
+	}
+
+	return version.str(), nil
+}
+
+// pluginVersion invokes the plugin's VERSION command and parses its output, including the CNI spec versions it
+// advertises support for.
+func (client *cniClient) pluginVersion(name string) (*cniPluginVersion, error) {
 	file := filepath.Join(client.pluginsPath, name)
 
 	// Check if the plugin file exists before executing it
 	_, err := os.Stat(file)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	cmd := exec.Command(file, versionCommand)
 	versionInfo, err := cmd.Output()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	version := &cniPluginVersion{}
@@ -158,13 +565,108 @@ func (client *cniClient) Version(name string) (string, error) {
 	// {"version":"2017.06.0","dirty":true,"gitShortHash":"226db36"}
 	// For Windows, it is of the format
 	// {"version":"2017.06.0","gitShortHash":"226db36","built":"2048-08-16T12:10:14-08:00"}
+	// Plugins that support CNI spec 0.4.0 additionally report:
+	// {"cniVersion":"0.4.0", ..., "supportedVersions":["0.3.1","0.4.0"]}
 	// Unmarshal this
 	err = json.Unmarshal(versionInfo, version)
 	if err != nil {
-		return "", errors.Wrapf(err, "ecscni: unmarshal version from string: %s", versionInfo)
+		return nil, errors.Wrapf(err, "ecscni: unmarshal version from string: %s", versionInfo)
 	}
 
-	return version.str(), nil
+	return version, nil
+}
+
+// negotiateListVersion picks the highest CNI spec version mutually supported by the agent and every plugin in
+// list's chain. A version is only usable for the whole list if every plugin in the chain advertises it, so this
+// intersects each plugin's supportedVersions before picking the highest mutual entry. If any plugin's version
+// can't be queried, it falls back to currentCNISpec and returns the query error.
+func (client *cniClient) negotiateListVersion(list *libcni.NetworkConfigList) (string, error) {
+	mutuallySupported := map[string]bool{}
+	for _, version := range supportedCNISpecs {
+		mutuallySupported[version] = true
+	}
+
+	for _, plugin := range list.Plugins {
+		pluginVersion, err := client.pluginVersion(plugin.Network.Type)
+		if err != nil {
+			return currentCNISpec, err
+		}
+
+		supportedByPlugin := map[string]bool{}
+		for _, version := range pluginVersion.SupportedVersions {
+			supportedByPlugin[version] = true
+		}
+		for version := range mutuallySupported {
+			if !supportedByPlugin[version] {
+				delete(mutuallySupported, version)
+			}
+		}
+	}
+
+	for _, version := range supportedCNISpecs {
+		if mutuallySupported[version] {
+			return version, nil
+		}
+	}
+
+	return currentCNISpec, nil
+}
+
+// buildCapabilityArgs converts runtimeCfg into a libcni.RuntimeConf.CapabilityArgs map, keeping only the
+// capabilities that at least one plugin in list's chain actually advertises support for. Requested capabilities
+// that no plugin in the chain supports are logged and skipped rather than failing the attachment, so that
+// adding a new capability to a task definition stays backward compatible with older plugin builds.
+func (client *cniClient) buildCapabilityArgs(list *libcni.NetworkConfigList, runtimeCfg *RuntimeConfig) map[string]interface{} {
+	if runtimeCfg == nil {
+		return nil
+	}
+
+	requested := map[string]interface{}{}
+	if runtimeCfg.MAC != "" {
+		requested[capabilityMAC] = runtimeCfg.MAC
+	}
+	if len(runtimeCfg.IPs) > 0 {
+		requested[capabilityIPs] = runtimeCfg.IPs
+	}
+	if runtimeCfg.Bandwidth != nil {
+		requested[capabilityBandwidth] = runtimeCfg.Bandwidth
+	}
+	if len(runtimeCfg.Aliases) > 0 {
+		requested[capabilityAliases] = runtimeCfg.Aliases
+	}
+	for name, value := range runtimeCfg.ExtraArgs {
+		requested[name] = value
+	}
+
+	if len(requested) == 0 {
+		return nil
+	}
+
+	supported := map[string]bool{}
+	for _, plugin := range list.Plugins {
+		capabilities, err := client.Capabilities(plugin.Network.Type)
+		if err != nil {
+			seelog.Warnf("[ECSCNI] Unable to query capabilities for plugin %s: %v", plugin.Network.Type, err)
+			continue
+		}
+		for _, capability := range capabilities {
+			supported[capability] = true
+		}
+	}
+
+	args := map[string]interface{}{}
+	for name, value := range requested {
+		if !supported[name] {
+			seelog.Warnf("[ECSCNI] Skipping unsupported cni capability %s for network %s", name, list.Name)
+			continue
+		}
+		args[name] = value
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+	return args
 }
 
 // Capabilities returns the capabilities supported by a plugin
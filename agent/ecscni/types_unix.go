@@ -0,0 +1,121 @@
+//go:build linux
+// +build linux
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ecscni
+
+import (
+	"github.com/containernetworking/cni/libcni"
+	"github.com/containernetworking/cni/pkg/types/current"
+)
+
+// Config contains all the information needed to set up the container namespace using the CNI plugins, such
+// as the network namespace to enter and the ordered list of logical networks to attach to it.
+type Config struct {
+	// ContainerID is the id of the container to set up the namespace for.
+	ContainerID string
+	// ContainerNetNS is the path to the container's network namespace.
+	ContainerNetNS string
+	// NetworkConfigs is the ordered list of logical networks (e.g. "bridge", "eni0") to attach to this
+	// container's namespace.
+	NetworkConfigs []*NetworkConfig
+}
+
+// NetworkConfig describes a single logical network, such as "bridge" or an ENI attachment, to attach to the
+// container namespace. A logical network may chain several CNI plugins together (for example the bridge
+// plugin followed by the portmap plugin); CNINetworkConfig carries that chain as a single NetworkConfigList so
+// it is added, removed, and checked atomically via AddNetworkList/DelNetworkList/CheckNetworkList.
+type NetworkConfig struct {
+	// IfName is the name of the interface the plugin chain should create within the container namespace.
+	IfName string
+	// Name identifies this logical network (e.g. "bridge", "eni0") for reporting in AttachmentResults.
+	Name string
+	// CNINetworkConfig is the ordered chain of CNI plugin configurations to invoke for this logical network.
+	CNINetworkConfig *libcni.NetworkConfigList
+	// RuntimeConfig carries capability-gated runtime options (MAC, static IPs, bandwidth, aliases) to request
+	// for this attachment. It may be nil if none are needed.
+	RuntimeConfig *RuntimeConfig
+}
+
+// RuntimeConfig carries capability-gated runtime options for a single logical network attachment, such as a
+// static MAC address, static IPs, per-task bandwidth limits, or extra DNS aliases. These are passed to the CNI
+// plugin chain via libcni.RuntimeConf.CapabilityArgs, and are only attached for capabilities the plugin chain
+// actually advertises support for; see cniClient.buildCapabilityArgs.
+type RuntimeConfig struct {
+	// MAC is the desired MAC address for the interface, passed as the "mac" capability arg.
+	MAC string
+	// IPs is the list of desired static IPs for the interface, passed as the "ips" capability arg.
+	IPs []string
+	// Bandwidth carries ingress/egress rate limits, passed as the "bandwidth" capability arg.
+	Bandwidth *BandwidthConfig
+	// Aliases is the list of desired DNS aliases for the interface, passed as the "aliases" capability arg.
+	Aliases []string
+	// ExtraArgs carries any other capability args, keyed by capability name, for plugins not otherwise modeled
+	// above.
+	ExtraArgs map[string]interface{}
+}
+
+// BandwidthConfig carries the ingress/egress rate limits, in bits per second, for the "bandwidth" CNI
+// capability implemented by the bandwidth plugin.
+type BandwidthConfig struct {
+	IngressRate  int64 `json:"ingressRate"`
+	IngressBurst int64 `json:"ingressBurst"`
+	EgressRate   int64 `json:"egressRate"`
+	EgressBurst  int64 `json:"egressBurst"`
+}
+
+// AttachmentResult is the outcome of attaching a single logical network to a container namespace.
+type AttachmentResult struct {
+	// Name is the logical network name, matching NetworkConfig.Name.
+	Name string
+	// IfName is the interface name created inside the container namespace for this attachment.
+	IfName string
+	// CNIVersion is the CNI spec version that was negotiated with the plugin chain for this attachment.
+	CNIVersion string
+	// Result is the CNI result returned by the plugin chain, carrying the IPs, routes, and DNS config it set up.
+	Result *current.Result
+	// Error is set if setting up this particular attachment failed; other attachments may still have succeeded.
+	Error error
+}
+
+// AttachmentResults is the outcome of setting up every logical network configured for a container namespace,
+// keyed by IfName.
+type AttachmentResults map[string]*AttachmentResult
+
+// ByIfName returns the attachment result for the given interface name, or nil if there isn't one.
+func (r AttachmentResults) ByIfName(ifName string) *AttachmentResult {
+	return r[ifName]
+}
+
+// cniPluginVersion is used to parse the JSON emitted by a CNI plugin's VERSION command.
+type cniPluginVersion struct {
+	Version           string   `json:"version"`
+	Dirty             bool     `json:"dirty"`
+	GitShortHash      string   `json:"gitShortHash"`
+	Built             string   `json:"built"`
+	SupportedVersions []string `json:"supportedVersions"`
+}
+
+// str generates a string for the Version, in the format of cni plugin version followed by git hash
+func (version *cniPluginVersion) str() string {
+	str := version.Version
+	if version.GitShortHash != "" {
+		str += "-" + version.GitShortHash
+	}
+	if version.Dirty {
+		str += "(dirty)"
+	}
+	return str
+}
@@ -0,0 +1,52 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package factory is used to create a Secrets Manager client.
+package factory
+
+import (
+	"github.com/aws/amazon-ecs-agent/agent/credentials"
+
+	"github.com/aws/aws-sdk-go/aws"
+	sdkcredentials "github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+)
+
+// SecretsManagerClientCreator is used to create a Secrets Manager client that's scoped to the given region and
+// credentials.
+type SecretsManagerClientCreator interface {
+	NewSecretsManagerClient(region string, creds credentials.IAMRoleCredentials) (secretsmanageriface.SecretsManagerAPI, error)
+}
+
+type secretsManagerClientCreator struct{}
+
+// NewSecretsManagerClientCreator creates a new SecretsManagerClientCreator.
+func NewSecretsManagerClientCreator() SecretsManagerClientCreator {
+	return &secretsManagerClientCreator{}
+}
+
+func (*secretsManagerClientCreator) NewSecretsManagerClient(region string,
+	creds credentials.IAMRoleCredentials) (secretsmanageriface.SecretsManagerAPI, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region: aws.String(region),
+		Credentials: sdkcredentials.NewStaticCredentials(
+			creds.AccessKeyID, creds.SecretAccessKey, creds.SessionToken),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return secretsmanager.New(sess), nil
+}
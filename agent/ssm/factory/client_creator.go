@@ -0,0 +1,50 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package factory is used to create an SSM client.
+package factory
+
+import (
+	"github.com/aws/amazon-ecs-agent/agent/credentials"
+
+	"github.com/aws/aws-sdk-go/aws"
+	sdkcredentials "github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+)
+
+// SSMClientCreator is used to create an SSM client that's scoped to the given region and credentials.
+type SSMClientCreator interface {
+	NewSSMClient(region string, creds credentials.IAMRoleCredentials) (ssmiface.SSMAPI, error)
+}
+
+type ssmClientCreator struct{}
+
+// NewSSMClientCreator creates a new SSMClientCreator.
+func NewSSMClientCreator() SSMClientCreator {
+	return &ssmClientCreator{}
+}
+
+func (*ssmClientCreator) NewSSMClient(region string, creds credentials.IAMRoleCredentials) (ssmiface.SSMAPI, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region: aws.String(region),
+		Credentials: sdkcredentials.NewStaticCredentials(
+			creds.AccessKeyID, creds.SecretAccessKey, creds.SessionToken),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ssm.New(sess), nil
+}
@@ -1,4 +1,6 @@
+//go:build linux
 // +build linux
+
 // Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
 //
 // Licensed under the Apache License, Version 2.0 (the "License"). You may
@@ -15,7 +17,15 @@
 package firelens
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -32,10 +42,22 @@ import (
 	"github.com/aws/amazon-ecs-agent/agent/credentials"
 	"github.com/aws/amazon-ecs-agent/agent/s3"
 	"github.com/aws/amazon-ecs-agent/agent/s3/factory"
+	secretsmanagerfactory "github.com/aws/amazon-ecs-agent/agent/secretsmanager/factory"
+	ssmfactory "github.com/aws/amazon-ecs-agent/agent/ssm/factory"
 	"github.com/aws/amazon-ecs-agent/agent/taskresource"
 	resourcestatus "github.com/aws/amazon-ecs-agent/agent/taskresource/status"
 	"github.com/aws/amazon-ecs-agent/agent/utils/ioutilwrapper"
 	"github.com/aws/amazon-ecs-agent/agent/utils/oswrapper"
+	"github.com/aws/amazon-ecs-agent/agent/utils/retry"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	sdkcredentials "github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	s3sdk "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager/s3manageriface"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/ssm"
 )
 
 const (
@@ -48,39 +70,101 @@ const (
 	// ecsMetadataEnableOption is the option that specifies whether to enable appending ecs metadata to log stream.
 	ecsLogMetadataEnableOption = "enable-ecs-log-metadata"
 	// ExternalConfigTypeOption is the option that specifies the type of an external config file to be included as
-	// part of the config file generated by agent. Its allowed values are "s3" and "file".
+	// part of the config file generated by agent. Its allowed values are "s3", "file", "https", "ssm", and
+	// "secretsmanager".
 	ExternalConfigTypeOption = "config-file-type"
 	// ExternalConfigTypeS3 means the firelens container is using a config file from S3.
 	ExternalConfigTypeS3 = "s3"
 	// ExternalConfigTypeFile means the firelens container is using a config file inside the container.
 	ExternalConfigTypeFile = "file"
+	// ExternalConfigTypeHTTPS means the firelens container is using a config file fetched from an HTTPS URL.
+	ExternalConfigTypeHTTPS = "https"
+	// ExternalConfigTypeSSM means the firelens container is using a config file stored as an SSM parameter.
+	ExternalConfigTypeSSM = "ssm"
+	// ExternalConfigTypeSecretsManager means the firelens container is using a config file stored as a Secrets
+	// Manager secret.
+	ExternalConfigTypeSecretsManager = "secretsmanager"
 	// externalConfigValueOption is the option that specifies the location of the external config file. When
 	// ExternalConfigTypeOption is s3, the value for this option should be an s3 arn; when ExternalConfigTypeOption is
-	// file, the value for this option should be a path to the config file inside the firelens container.
+	// file, the value for this option should be a path to the config file inside the firelens container; when
+	// ExternalConfigTypeOption is https, the value for this option should be a URL; when ExternalConfigTypeOption is
+	// ssm or secretsmanager, the value for this option should be an SSM parameter name or a Secrets Manager secret
+	// name/ARN.
 	externalConfigValueOption = "config-file-value"
-
-	s3DownloadTimeout = 30 * time.Second
+	// externalConfigSHA256Option is the option that specifies the expected sha256 digest (hex-encoded) of the
+	// downloaded external config file from S3. If specified, the agent verifies the downloaded bytes against it
+	// before the config is used, and fails resource creation on a mismatch.
+	externalConfigSHA256Option = "config-file-sha256"
+	// externalConfigETagOption is the option that specifies the expected S3 ETag of the external config file. If
+	// specified, the agent asserts it via a HEAD request before downloading the object.
+	externalConfigETagOption = "config-file-etag"
+	// externalConfigValuesOption is the option that specifies additional external config files to be included,
+	// beyond the single one named by ExternalConfigTypeOption/externalConfigValueOption. Its value is either a
+	// JSON-encoded list of {"type": ..., "value": ...} objects, or a comma-separated list of values whose types are
+	// given positionally by externalConfigTypesOption (or, if that's a single value, applied to every entry).
+	externalConfigValuesOption = "config-file-values"
+	// externalConfigTypesOption gives the types for externalConfigValuesOption when that option is a comma-separated
+	// list of values rather than a JSON-encoded list of {type, value} objects.
+	externalConfigTypesOption = "config-file-types"
+
+	// externalConfigDownloadConcurrency bounds how many S3 entries from externalConfigValuesOption are downloaded
+	// concurrently.
+	externalConfigDownloadConcurrency = 5
+
+	s3DownloadTimeout              = 30 * time.Second
+	httpsDownloadTimeout           = 30 * time.Second
+	ssmGetParameterTimeout         = 30 * time.Second
+	secretsManagerGetSecretTimeout = 30 * time.Second
+
+	// httpsConfigScheme is the only URL scheme downloadHTTPSConfigTo will fetch from; task authors cannot point
+	// this at plaintext http:// or local file:// sources.
+	httpsConfigScheme = "https"
+	// httpsConfigMaxBytes bounds how much of an https config response is read, since these are small text
+	// configs, not arbitrary downloads.
+	httpsConfigMaxBytes = 10 * 1024 * 1024
+
+	// s3DownloadMinBackoff, s3DownloadMaxBackoff, s3DownloadBackoffJitter, and s3DownloadBackoffMultiple configure
+	// the exponential backoff applied between retries of a failed S3 config download.
+	s3DownloadMinBackoff      = 1 * time.Second
+	s3DownloadMaxBackoff      = 8 * time.Second
+	s3DownloadBackoffJitter   = 0.2
+	s3DownloadBackoffMultiple = 2.0
+	s3DownloadMaxRetries      = 5
+	s3DownloadTotalDeadline   = 2 * time.Minute
 )
 
+// ExternalConfig represents a single external firelens config file to be downloaded and included by reference, in
+// addition to the one named by FirelensResource's externalConfigType/externalConfigValue fields. Order matters: the
+// generated config includes entries in the order they appear here.
+type ExternalConfig struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
 // FirelensResource models fluentd/fluentbit firelens container related resources as a task resource.
 type FirelensResource struct {
 	// Fields that are specific to firelens resource. They are only set at initialization so are not protected by lock.
-	cluster                string
-	taskARN                string
-	taskDefinition         string
-	ec2InstanceID          string
-	resourceDir            string
-	firelensConfigType     string
-	region                 string
-	ecsMetadataEnabled     bool
-	containerToLogOptions  map[string]map[string]string
-	credentialsManager     credentials.Manager
-	executionCredentialsID string
-	externalConfigType     string
-	externalConfigValue    string
-	networkMode            string
-	ioutil                 ioutilwrapper.IOUtil
-	s3ClientCreator        factory.S3ClientCreator
+	cluster                     string
+	taskARN                     string
+	taskDefinition              string
+	ec2InstanceID               string
+	resourceDir                 string
+	firelensConfigType          string
+	region                      string
+	ecsMetadataEnabled          bool
+	containerToLogOptions       map[string]map[string]string
+	credentialsManager          credentials.Manager
+	executionCredentialsID      string
+	externalConfigType          string
+	externalConfigValue         string
+	externalConfigSHA256        string
+	externalConfigETag          string
+	externalConfigs             []ExternalConfig
+	networkMode                 string
+	ioutil                      ioutilwrapper.IOUtil
+	s3ClientCreator             factory.S3ClientCreator
+	ssmClientCreator            ssmfactory.SSMClientCreator
+	secretsManagerClientCreator secretsmanagerfactory.SecretsManagerClientCreator
 
 	// Fields for the common functionality of task resource. Access to these fields are protected by lock.
 	createdAtUnsafe     time.Time
@@ -98,18 +182,20 @@ func NewFirelensResource(cluster, taskARN, taskDefinition, ec2InstanceID, dataDi
 	firelensOptions map[string]string, containerToLogOptions map[string]map[string]string, credentialsManager credentials.Manager,
 	executionCredentialsID string) (*FirelensResource, error) {
 	firelensResource := &FirelensResource{
-		cluster:                cluster,
-		taskARN:                taskARN,
-		taskDefinition:         taskDefinition,
-		ec2InstanceID:          ec2InstanceID,
-		firelensConfigType:     firelensConfigType,
-		region:                 region,
-		networkMode:            networkMode,
-		containerToLogOptions:  containerToLogOptions,
-		ioutil:                 ioutilwrapper.NewIOUtil(),
-		s3ClientCreator:        factory.NewS3ClientCreator(),
-		executionCredentialsID: executionCredentialsID,
-		credentialsManager:     credentialsManager,
+		cluster:                     cluster,
+		taskARN:                     taskARN,
+		taskDefinition:              taskDefinition,
+		ec2InstanceID:               ec2InstanceID,
+		firelensConfigType:          firelensConfigType,
+		region:                      region,
+		networkMode:                 networkMode,
+		containerToLogOptions:       containerToLogOptions,
+		ioutil:                      ioutilwrapper.NewIOUtil(),
+		s3ClientCreator:             factory.NewS3ClientCreator(),
+		ssmClientCreator:            ssmfactory.NewSSMClientCreator(),
+		secretsManagerClientCreator: secretsmanagerfactory.NewSecretsManagerClientCreator(),
+		executionCredentialsID:      executionCredentialsID,
+		credentialsManager:          credentialsManager,
 	}
 
 	fields := strings.Split(taskARN, "/")
@@ -125,6 +211,111 @@ func NewFirelensResource(cluster, taskARN, taskDefinition, ec2InstanceID, dataDi
 	return firelensResource, nil
 }
 
+// FirelensResourceJSON duplicates FirelensResource's fields for marshalling and unmarshalling, since
+// FirelensResource itself carries a lock and client creators that can't be serialized directly.
+type FirelensResourceJSON struct {
+	Cluster                string                         `json:"cluster"`
+	TaskARN                string                         `json:"taskARN"`
+	TaskDefinition         string                         `json:"taskDefinition"`
+	EC2InstanceID          string                         `json:"ec2InstanceID"`
+	ResourceDir            string                         `json:"resourceDir"`
+	FirelensConfigType     string                         `json:"firelensConfigType"`
+	Region                 string                         `json:"region"`
+	ECSMetadataEnabled     bool                           `json:"ecsMetadataEnabled"`
+	ContainerToLogOptions  map[string]map[string]string   `json:"containerToLogOptions"`
+	ExecutionCredentialsID string                         `json:"executionCredentialsID"`
+	ExternalConfigType     string                         `json:"externalConfigType,omitempty"`
+	ExternalConfigValue    string                         `json:"externalConfigValue,omitempty"`
+	ExternalConfigSHA256   string                         `json:"externalConfigSHA256,omitempty"`
+	ExternalConfigETag     string                         `json:"externalConfigETag,omitempty"`
+	ExternalConfigs        []ExternalConfig               `json:"externalConfigs,omitempty"`
+	NetworkMode            string                         `json:"networkMode"`
+	CreatedAt              *time.Time                     `json:"createdAt,omitempty"`
+	DesiredStatus          *resourcestatus.ResourceStatus `json:"desiredStatus"`
+	KnownStatus            *resourcestatus.ResourceStatus `json:"knownStatus"`
+	AppliedStatus          *resourcestatus.ResourceStatus `json:"appliedStatus,omitempty"`
+	TerminalReason         string                         `json:"terminalReason,omitempty"`
+}
+
+// MarshalJSON marshals FirelensResource into state file bytes, so that the external config list and the
+// single-file sha256/etag survive an agent restart along with the rest of the resource's state.
+func (firelens *FirelensResource) MarshalJSON() ([]byte, error) {
+	if firelens == nil {
+		return []byte("null"), nil
+	}
+	firelens.lock.RLock()
+	defer firelens.lock.RUnlock()
+
+	createdAt := firelens.createdAtUnsafe
+	return json.Marshal(FirelensResourceJSON{
+		Cluster:                firelens.cluster,
+		TaskARN:                firelens.taskARN,
+		TaskDefinition:         firelens.taskDefinition,
+		EC2InstanceID:          firelens.ec2InstanceID,
+		ResourceDir:            firelens.resourceDir,
+		FirelensConfigType:     firelens.firelensConfigType,
+		Region:                 firelens.region,
+		ECSMetadataEnabled:     firelens.ecsMetadataEnabled,
+		ContainerToLogOptions:  firelens.containerToLogOptions,
+		ExecutionCredentialsID: firelens.executionCredentialsID,
+		ExternalConfigType:     firelens.externalConfigType,
+		ExternalConfigValue:    firelens.externalConfigValue,
+		ExternalConfigSHA256:   firelens.externalConfigSHA256,
+		ExternalConfigETag:     firelens.externalConfigETag,
+		ExternalConfigs:        firelens.externalConfigs,
+		NetworkMode:            firelens.networkMode,
+		CreatedAt:              &createdAt,
+		DesiredStatus:          &firelens.desiredStatusUnsafe,
+		KnownStatus:            &firelens.knownStatusUnsafe,
+		AppliedStatus:          &firelens.appliedStatusUnsafe,
+		TerminalReason:         firelens.terminalReason,
+	})
+}
+
+// UnmarshalJSON unmarshals state file bytes into FirelensResource. Fields not covered by FirelensResourceJSON
+// (client creators, the io wrapper, the status-to-transition map) are left for Initialize to populate.
+func (firelens *FirelensResource) UnmarshalJSON(b []byte) error {
+	temp := FirelensResourceJSON{}
+	if err := json.Unmarshal(b, &temp); err != nil {
+		return err
+	}
+
+	firelens.lock.Lock()
+	defer firelens.lock.Unlock()
+
+	firelens.cluster = temp.Cluster
+	firelens.taskARN = temp.TaskARN
+	firelens.taskDefinition = temp.TaskDefinition
+	firelens.ec2InstanceID = temp.EC2InstanceID
+	firelens.resourceDir = temp.ResourceDir
+	firelens.firelensConfigType = temp.FirelensConfigType
+	firelens.region = temp.Region
+	firelens.ecsMetadataEnabled = temp.ECSMetadataEnabled
+	firelens.containerToLogOptions = temp.ContainerToLogOptions
+	firelens.executionCredentialsID = temp.ExecutionCredentialsID
+	firelens.externalConfigType = temp.ExternalConfigType
+	firelens.externalConfigValue = temp.ExternalConfigValue
+	firelens.externalConfigSHA256 = temp.ExternalConfigSHA256
+	firelens.externalConfigETag = temp.ExternalConfigETag
+	firelens.externalConfigs = temp.ExternalConfigs
+	firelens.networkMode = temp.NetworkMode
+	firelens.terminalReason = temp.TerminalReason
+	if temp.CreatedAt != nil {
+		firelens.createdAtUnsafe = *temp.CreatedAt
+	}
+	if temp.DesiredStatus != nil {
+		firelens.desiredStatusUnsafe = *temp.DesiredStatus
+	}
+	if temp.KnownStatus != nil {
+		firelens.knownStatusUnsafe = *temp.KnownStatus
+	}
+	if temp.AppliedStatus != nil {
+		firelens.appliedStatusUnsafe = *temp.AppliedStatus
+	}
+
+	return nil
+}
+
 func (firelens *FirelensResource) parseOptions(options map[string]string) error {
 	if _, ok := options[ecsLogMetadataEnableOption]; ok {
 		val := options[ecsLogMetadataEnableOption]
@@ -139,8 +330,8 @@ func (firelens *FirelensResource) parseOptions(options map[string]string) error
 	}
 
 	if externalConfigType, ok := options[ExternalConfigTypeOption]; ok {
-		if externalConfigType != ExternalConfigTypeS3 && externalConfigType != ExternalConfigTypeFile {
-			return errors.Errorf("invalid value %s is specified for option %s", externalConfigType, ExternalConfigTypeOption)
+		if err := validateExternalConfigType(externalConfigType, ExternalConfigTypeOption); err != nil {
+			return err
 		}
 		firelens.externalConfigType = externalConfigType
 
@@ -149,11 +340,123 @@ func (firelens *FirelensResource) parseOptions(options map[string]string) error
 			return errors.Errorf("option %s is specified but %s is not specified", ExternalConfigTypeOption, externalConfigValueOption)
 		}
 		firelens.externalConfigValue = externalConfigValue
+		firelens.externalConfigSHA256 = options[externalConfigSHA256Option]
+		firelens.externalConfigETag = options[externalConfigETagOption]
+	}
+
+	if rawValues, ok := options[externalConfigValuesOption]; ok && rawValues != "" {
+		configs, err := parseExternalConfigValues(rawValues, options[externalConfigTypesOption], firelens.externalConfigType)
+		if err != nil {
+			return err
+		}
+		firelens.externalConfigs = configs
+	}
+
+	if err := validateNoDuplicateExternalConfigSources(firelens.externalConfigType, firelens.externalConfigValue, firelens.externalConfigs); err != nil {
+		return err
 	}
 
 	return nil
 }
 
+// validateExternalConfigType returns an error unless configType is one of the supported external config types.
+// optionName is used only to produce a useful error message.
+func validateExternalConfigType(configType, optionName string) error {
+	switch configType {
+	case ExternalConfigTypeS3, ExternalConfigTypeFile, ExternalConfigTypeHTTPS, ExternalConfigTypeSSM,
+		ExternalConfigTypeSecretsManager:
+		return nil
+	default:
+		return errors.Errorf("invalid value %s is specified for option %s", configType, optionName)
+	}
+}
+
+// parseExternalConfigValues parses the externalConfigValuesOption option. rawValues is either a JSON-encoded list
+// of {"type": ..., "value": ...} objects, or a comma-separated list of values whose types are given positionally by
+// rawTypes (itself comma-separated). If rawTypes has exactly one entry, it's applied to every value; if rawTypes is
+// empty, defaultType (the legacy ExternalConfigTypeOption, if any) is applied to every value instead.
+func parseExternalConfigValues(rawValues, rawTypes, defaultType string) ([]ExternalConfig, error) {
+	var configs []ExternalConfig
+	if err := json.Unmarshal([]byte(rawValues), &configs); err == nil {
+		for _, config := range configs {
+			if err := validateExternalConfigType(config.Type, externalConfigTypesOption); err != nil {
+				return nil, err
+			}
+		}
+		return configs, nil
+	}
+
+	values := splitAndTrimCSV(rawValues)
+	types := splitAndTrimCSV(rawTypes)
+
+	switch {
+	case len(types) == 0 && defaultType == "":
+		return nil, errors.Errorf("option %s is specified but neither %s nor %s specifies a type",
+			externalConfigValuesOption, externalConfigTypesOption, ExternalConfigTypeOption)
+	case len(types) == 0:
+		types = repeat(defaultType, len(values))
+	case len(types) == 1:
+		types = repeat(types[0], len(values))
+	case len(types) != len(values):
+		return nil, errors.Errorf("option %s has %d entries but option %s has %d entries",
+			externalConfigValuesOption, len(values), externalConfigTypesOption, len(types))
+	}
+
+	configs = make([]ExternalConfig, len(values))
+	for i, value := range values {
+		if err := validateExternalConfigType(types[i], externalConfigTypesOption); err != nil {
+			return nil, err
+		}
+		configs[i] = ExternalConfig{Type: types[i], Value: value}
+	}
+	return configs, nil
+}
+
+// splitAndTrimCSV splits a comma-separated string into its trimmed, non-empty parts.
+func splitAndTrimCSV(raw string) []string {
+	if raw == "" {
+		return nil
+	}
+	parts := strings.Split(raw, ",")
+	trimmed := make([]string, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			trimmed = append(trimmed, part)
+		}
+	}
+	return trimmed
+}
+
+func repeat(value string, n int) []string {
+	values := make([]string, n)
+	for i := range values {
+		values[i] = value
+	}
+	return values
+}
+
+// validateNoDuplicateExternalConfigSources rejects a set of external config options that would download the same
+// {type, value} source more than once, since that's almost certainly a copy-paste mistake rather than an
+// intentional config, and also rejects any config-file-values entry with an empty value, which would silently
+// download nothing into its included file.
+func validateNoDuplicateExternalConfigSources(legacyType, legacyValue string, configs []ExternalConfig) error {
+	seen := make(map[ExternalConfig]struct{}, len(configs)+1)
+	if legacyType != "" {
+		seen[ExternalConfig{Type: legacyType, Value: legacyValue}] = struct{}{}
+	}
+	for _, config := range configs {
+		if config.Value == "" {
+			return errors.Errorf("empty value specified for external config of type %s", config.Type)
+		}
+		if _, ok := seen[config]; ok {
+			return errors.Errorf("duplicate external config source for type %s value %s", config.Type, config.Value)
+		}
+		seen[config] = struct{}{}
+	}
+	return nil
+}
+
 // GetCluster returns the cluster.
 func (firelens *FirelensResource) GetCluster() string {
 	return firelens.cluster
@@ -205,6 +508,12 @@ func (firelens *FirelensResource) GetExternalConfigValue() string {
 	return firelens.externalConfigValue
 }
 
+// GetExternalConfigs returns the additional external config entries configured via config-file-values, in
+// declared order.
+func (firelens *FirelensResource) GetExternalConfigs() []ExternalConfig {
+	return firelens.externalConfigs
+}
+
 // Initialize initializes the resource.
 func (firelens *FirelensResource) Initialize(resourceFields *taskresource.ResourceFields,
 	taskKnownStatus status.TaskStatus, taskDesiredStatus status.TaskStatus) {
@@ -215,6 +524,8 @@ func (firelens *FirelensResource) Initialize(resourceFields *taskresource.Resour
 	firelens.initStatusToTransition()
 	firelens.ioutil = ioutilwrapper.NewIOUtil()
 	firelens.s3ClientCreator = factory.NewS3ClientCreator()
+	firelens.ssmClientCreator = ssmfactory.NewSSMClientCreator()
+	firelens.secretsManagerClientCreator = secretsmanagerfactory.NewSecretsManagerClientCreator()
 	firelens.credentialsManager = resourceFields.CredentialsManager
 }
 
@@ -408,13 +719,44 @@ func (firelens *FirelensResource) Create() error {
 		return err
 	}
 
-	if firelens.externalConfigType == ExternalConfigTypeS3 {
+	switch firelens.externalConfigType {
+	case ExternalConfigTypeS3:
 		err = firelens.downloadConfigFromS3()
 		if err != nil {
 			err = errors.Wrap(err, "unable to download firelens s3 config file")
 			firelens.setTerminalReason(err.Error())
 			return err
 		}
+	case ExternalConfigTypeHTTPS:
+		err = firelens.downloadConfigFromHTTPS()
+		if err != nil {
+			err = errors.Wrap(err, "unable to download firelens https config file")
+			firelens.setTerminalReason(err.Error())
+			return err
+		}
+	case ExternalConfigTypeSSM:
+		err = firelens.downloadConfigFromSSM()
+		if err != nil {
+			err = errors.Wrap(err, "unable to download firelens ssm config file")
+			firelens.setTerminalReason(err.Error())
+			return err
+		}
+	case ExternalConfigTypeSecretsManager:
+		err = firelens.downloadConfigFromSecretsManager()
+		if err != nil {
+			err = errors.Wrap(err, "unable to download firelens secrets manager config file")
+			firelens.setTerminalReason(err.Error())
+			return err
+		}
+	}
+
+	if len(firelens.externalConfigs) > 0 {
+		err = firelens.downloadExternalConfigs()
+		if err != nil {
+			err = errors.Wrap(err, "unable to download firelens external config files")
+			firelens.setTerminalReason(err.Error())
+			return err
+		}
 	}
 
 	err = firelens.generateConfigFile()
@@ -430,11 +772,12 @@ func (firelens *FirelensResource) Create() error {
 var mkdirAll = os.MkdirAll
 
 // createDirectories creates two directories:
-//  - $(DATA_DIR)/firelens/$(TASK_ID)/config: used to store firelens config file. The config file under this directory
-//    will be mounted to the firelens container at an expected path.
-//  - $(DATA_DIR)/firelens/$(TASK_ID)/socket: used to store the unix socket. This directory will be mounted to
-//    the firelens container and it will generate a socket file under this directory. Containers that use firelens to
-//    send logs will then use this socket to send logs to the firelens container.
+//   - $(DATA_DIR)/firelens/$(TASK_ID)/config: used to store firelens config file. The config file under this directory
+//     will be mounted to the firelens container at an expected path.
+//   - $(DATA_DIR)/firelens/$(TASK_ID)/socket: used to store the unix socket. This directory will be mounted to
+//     the firelens container and it will generate a socket file under this directory. Containers that use firelens to
+//     send logs will then use this socket to send logs to the firelens container.
+//
 // Note: socket path has a limit of at most 108 characters on Linux. If using default data dir, the
 // resulting socket path will be 79 characters (/var/lib/ecs/data/firelens/<task-id>/socket/fluent.sock) which is fine.
 // However if ECS_HOST_DATA_DIR is specified to be a longer path, we will exceed the limit and fail. I don't really
@@ -465,10 +808,14 @@ func (firelens *FirelensResource) generateConfigFile() error {
 	confFilePath := filepath.Join(firelens.resourceDir, "config", "fluent.conf")
 	err = firelens.writeConfigFile(func(file oswrapper.File) error {
 		if firelens.firelensConfigType == FirelensConfigTypeFluentd {
-			return config.WriteFluentdConfig(file)
+			err = config.WriteFluentdConfig(file)
 		} else {
-			return config.WriteFluentBitConfig(file)
+			err = config.WriteFluentBitConfig(file)
 		}
+		if err != nil {
+			return err
+		}
+		return firelens.writeExternalConfigIncludes(file)
 	}, confFilePath)
 	if err != nil {
 		return errors.Wrapf(err, "unable to generate firelens config file")
@@ -478,8 +825,33 @@ func (firelens *FirelensResource) generateConfigFile() error {
 	return nil
 }
 
+// writeExternalConfigIncludes appends an include directive for each entry in firelens.externalConfigs, in the
+// order they were declared, so the generated config pulls in every downloaded external_<n>.conf file.
+func (firelens *FirelensResource) writeExternalConfigIncludes(file oswrapper.File) error {
+	for i := range firelens.externalConfigs {
+		path := firelens.externalConfigFilePathN(i)
+
+		var line string
+		if firelens.firelensConfigType == FirelensConfigTypeFluentd {
+			line = fmt.Sprintf("@include %s\n", path)
+		} else {
+			// Fluent Bit's classic config format uses an @INCLUDE directive, the counterpart of fluentd's @include,
+			// not an [INCLUDE] section (which isn't a real Fluent Bit directive and would fail the router at startup).
+			line = fmt.Sprintf("@INCLUDE %s\n", path)
+		}
+
+		if _, err := file.Write([]byte(line)); err != nil {
+			return errors.Wrapf(err, "unable to write include directive for %s", path)
+		}
+	}
+	return nil
+}
+
 // downloadConfigFromS3 downloads an external config file from S3 and saves it at ${RESOURCE_DIR}/config/external.conf.
-// The generated firelens config file fluent.conf will have a reference to include this file.
+// The generated firelens config file fluent.conf will have a reference to include this file. The download is
+// retried with exponential backoff on transient failures. When configured, the object's ETag is asserted with a
+// HEAD request before the download, and the downloaded bytes are verified against the sha256 digest before the
+// config is allowed to take effect.
 func (firelens *FirelensResource) downloadConfigFromS3() error {
 	creds, ok := firelens.credentialsManager.GetTaskCredentials(firelens.executionCredentialsID)
 	if !ok {
@@ -496,19 +868,343 @@ func (firelens *FirelensResource) downloadConfigFromS3() error {
 		return errors.Wrapf(err, "unable to initialize s3 client for bucket %s", bucket)
 	}
 
-	confFilePath := filepath.Join(firelens.resourceDir, "config", "external.conf")
-	err = firelens.writeConfigFile(func(file oswrapper.File) error {
-		return s3.DownloadFile(bucket, key, s3DownloadTimeout, file, s3Client)
-	}, confFilePath)
+	if firelens.externalConfigETag != "" {
+		if err := firelens.assertS3ObjectETag(bucket, key, firelens.externalConfigETag, creds.GetIAMRoleCredentials()); err != nil {
+			return errors.Wrapf(err, "etag mismatch for s3 config %s in bucket %s", key, bucket)
+		}
+	}
+
+	confFilePath := firelens.externalConfigFilePath()
+	ctx, cancel := context.WithTimeout(context.Background(), s3DownloadTotalDeadline)
+	defer cancel()
+
+	backoff := retry.NewExponentialBackoff(s3DownloadMinBackoff, s3DownloadMaxBackoff, s3DownloadBackoffJitter,
+		s3DownloadBackoffMultiple)
+
+	attempt := 0
+	err = retry.RetryNWithBackoffCtx(ctx, backoff, s3DownloadMaxRetries, func() error {
+		attempt++
+		seelog.Infof("Downloading firelens s3 config %s from bucket %s (attempt %d/%d)", key, bucket, attempt, s3DownloadMaxRetries)
+
+		downloadErr := firelens.writeConfigFileVerified(func(file oswrapper.File) error {
+			return s3.DownloadFile(bucket, key, s3DownloadTimeout, file, s3Client)
+		}, confFilePath, firelens.externalConfigSHA256)
+		if downloadErr == nil {
+			return nil
+		}
 
+		seelog.Warnf("Attempt %d/%d to download firelens s3 config %s from bucket %s failed: %v",
+			attempt, s3DownloadMaxRetries, key, bucket, downloadErr)
+		if isNonRetryableS3Error(downloadErr) {
+			return retry.NewRetriableError(retry.NewRetriable(false), downloadErr)
+		}
+		return downloadErr
+	})
 	if err != nil {
-		return errors.Wrapf(err, "unable to download s3 config %s from bucket %s", key, bucket)
+		return errors.Wrapf(err, "unable to download s3 config %s from bucket %s after %d attempts", key, bucket, attempt)
 	}
 
 	seelog.Debugf("Downloaded firelens config file from s3 and saved to: %s", confFilePath)
 	return nil
 }
 
+// assertS3ObjectETag issues a HEAD request against the s3 object at bucket/key and fails if its ETag does not
+// match expectedETag. s3ClientCreator only hands back a DownloaderAPI, which doesn't expose HeadObject, so this
+// builds its own lightweight s3 client from the same region/credentials rather than widening that interface.
+func (firelens *FirelensResource) assertS3ObjectETag(bucket, key, expectedETag string, creds credentials.IAMRoleCredentials) error {
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String(firelens.region),
+		Credentials: sdkcredentials.NewStaticCredentials(creds.AccessKeyID, creds.SecretAccessKey, creds.SessionToken),
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to create aws session for s3 head request")
+	}
+
+	resp, err := s3sdk.New(sess).HeadObject(&s3sdk.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "unable to head s3 object %s in bucket %s", key, bucket)
+	}
+
+	actualETag := strings.Trim(aws.StringValue(resp.ETag), `"`)
+	expected := strings.Trim(expectedETag, `"`)
+	if !strings.EqualFold(actualETag, expected) {
+		return errors.Errorf("expected etag %s but got %s", expected, actualETag)
+	}
+
+	return nil
+}
+
+// isNonRetryableS3Error returns true for errors that will not succeed on retry: access-denied/not-found
+// responses from S3, and a sha256 mismatch on the downloaded bytes (which is deterministic for a given set of
+// bytes, so retrying the same immutable object cannot fix it). The retry loop fails fast on these instead of
+// burning through its backoff budget.
+func isNonRetryableS3Error(err error) bool {
+	cause := errors.Cause(err)
+
+	if reqErr, ok := cause.(awserr.RequestFailure); ok {
+		switch reqErr.StatusCode() {
+		case http.StatusForbidden, http.StatusNotFound:
+			return true
+		}
+	}
+
+	if _, ok := cause.(*sha256MismatchError); ok {
+		return true
+	}
+
+	return false
+}
+
+// externalConfigFilePath returns the path at which the downloaded external config file is saved.
+func (firelens *FirelensResource) externalConfigFilePath() string {
+	return filepath.Join(firelens.resourceDir, "config", "external.conf")
+}
+
+// downloadConfigFromHTTPS downloads an external config file from an HTTPS URL and saves it at
+// ${RESOURCE_DIR}/config/external.conf. The generated firelens config file fluent.conf will have a reference to
+// include this file.
+func (firelens *FirelensResource) downloadConfigFromHTTPS() error {
+	return firelens.downloadHTTPSConfigTo(firelens.externalConfigValue, firelens.externalConfigFilePath())
+}
+
+// downloadHTTPSConfigTo fetches an external config file from an HTTPS URL and saves it at destPath. Only the
+// https scheme is accepted, and the response body is capped at httpsConfigMaxBytes.
+func (firelens *FirelensResource) downloadHTTPSConfigTo(rawURL, destPath string) error {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return errors.Wrapf(err, "unable to parse https config url %s", rawURL)
+	}
+	if parsedURL.Scheme != httpsConfigScheme {
+		return errors.Errorf("unsupported scheme %q for firelens config-file-value, only %s is allowed",
+			parsedURL.Scheme, httpsConfigScheme)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), httpsDownloadTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return errors.Wrap(err, "unable to construct request for https config")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "unable to fetch https config from %s", rawURL)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status %d fetching https config from %s", resp.StatusCode, rawURL)
+	}
+
+	err = firelens.writeConfigFile(func(file oswrapper.File) error {
+		written, err := io.Copy(file, io.LimitReader(resp.Body, httpsConfigMaxBytes+1))
+		if err != nil {
+			return err
+		}
+		if written > httpsConfigMaxBytes {
+			return errors.Errorf("https config from %s exceeds maximum allowed size of %d bytes",
+				rawURL, httpsConfigMaxBytes)
+		}
+		return nil
+	}, destPath)
+	if err != nil {
+		return errors.Wrapf(err, "unable to save https config from %s", rawURL)
+	}
+
+	seelog.Debugf("Downloaded firelens config file from %s and saved to: %s", rawURL, destPath)
+	return nil
+}
+
+// downloadConfigFromSSM fetches an external config file from an SSM parameter and saves it at
+// ${RESOURCE_DIR}/config/external.conf. The generated firelens config file fluent.conf will have a reference to
+// include this file.
+func (firelens *FirelensResource) downloadConfigFromSSM() error {
+	return firelens.downloadSSMConfigTo(firelens.externalConfigValue, firelens.externalConfigFilePath())
+}
+
+// downloadSSMConfigTo fetches an external config file from the named SSM parameter and saves it at destPath.
+func (firelens *FirelensResource) downloadSSMConfigTo(parameterName, destPath string) error {
+	creds, ok := firelens.credentialsManager.GetTaskCredentials(firelens.executionCredentialsID)
+	if !ok {
+		return errors.New("unable to get execution role credentials")
+	}
+
+	ssmClient, err := firelens.ssmClientCreator.NewSSMClient(firelens.region, creds.GetIAMRoleCredentials())
+	if err != nil {
+		return errors.Wrap(err, "unable to initialize ssm client")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ssmGetParameterTimeout)
+	defer cancel()
+
+	resp, err := ssmClient.GetParameterWithContext(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(parameterName),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "unable to get ssm parameter %s", parameterName)
+	}
+
+	err = firelens.writeConfigFile(func(file oswrapper.File) error {
+		_, err := file.Write([]byte(aws.StringValue(resp.Parameter.Value)))
+		return err
+	}, destPath)
+	if err != nil {
+		return errors.Wrapf(err, "unable to save ssm parameter %s", parameterName)
+	}
+
+	seelog.Debugf("Fetched firelens config file from ssm parameter %s and saved to: %s", parameterName, destPath)
+	return nil
+}
+
+// downloadConfigFromSecretsManager fetches an external config file from a Secrets Manager secret and saves it at
+// ${RESOURCE_DIR}/config/external.conf. The generated firelens config file fluent.conf will have a reference to
+// include this file.
+func (firelens *FirelensResource) downloadConfigFromSecretsManager() error {
+	return firelens.downloadSecretsManagerConfigTo(firelens.externalConfigValue, firelens.externalConfigFilePath())
+}
+
+// downloadSecretsManagerConfigTo fetches an external config file from the named Secrets Manager secret and saves
+// it at destPath.
+func (firelens *FirelensResource) downloadSecretsManagerConfigTo(secretID, destPath string) error {
+	creds, ok := firelens.credentialsManager.GetTaskCredentials(firelens.executionCredentialsID)
+	if !ok {
+		return errors.New("unable to get execution role credentials")
+	}
+
+	secretsManagerClient, err := firelens.secretsManagerClientCreator.NewSecretsManagerClient(firelens.region, creds.GetIAMRoleCredentials())
+	if err != nil {
+		return errors.Wrap(err, "unable to initialize secrets manager client")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretsManagerGetSecretTimeout)
+	defer cancel()
+
+	resp, err := secretsManagerClient.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(secretID),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "unable to get secrets manager secret %s", secretID)
+	}
+
+	secretValue := aws.StringValue(resp.SecretString)
+	if secretValue == "" {
+		secretValue = string(resp.SecretBinary)
+	}
+
+	err = firelens.writeConfigFile(func(file oswrapper.File) error {
+		_, err := file.Write([]byte(secretValue))
+		return err
+	}, destPath)
+	if err != nil {
+		return errors.Wrapf(err, "unable to save secrets manager secret %s", secretID)
+	}
+
+	seelog.Debugf("Fetched firelens config file from secrets manager secret %s and saved to: %s", secretID, destPath)
+	return nil
+}
+
+// externalConfigFilePathN returns the path at which the n-th entry of firelens.externalConfigs is saved.
+func (firelens *FirelensResource) externalConfigFilePathN(n int) string {
+	return filepath.Join(firelens.resourceDir, "config", fmt.Sprintf("external_%d.conf", n))
+}
+
+// downloadExternalConfigs downloads every entry in firelens.externalConfigs to
+// ${RESOURCE_DIR}/config/external_<n>.conf, in declared order. S3 entries are downloaded concurrently through a
+// bounded worker pool that shares one S3 client per bucket; the other entry types each hit a single external
+// endpoint rather than many objects in a bucket, so they're downloaded serially.
+func (firelens *FirelensResource) downloadExternalConfigs() error {
+	s3Clients := make(map[string]s3manageriface.DownloaderAPI)
+	var s3ClientsLock sync.Mutex
+
+	getS3Client := func(bucket string) (s3manageriface.DownloaderAPI, error) {
+		s3ClientsLock.Lock()
+		defer s3ClientsLock.Unlock()
+
+		if client, ok := s3Clients[bucket]; ok {
+			return client, nil
+		}
+
+		creds, ok := firelens.credentialsManager.GetTaskCredentials(firelens.executionCredentialsID)
+		if !ok {
+			return nil, errors.New("unable to get execution role credentials")
+		}
+		client, err := firelens.s3ClientCreator.NewS3ClientForBucket(bucket, firelens.region, creds.GetIAMRoleCredentials())
+		if err != nil {
+			return nil, err
+		}
+		s3Clients[bucket] = client
+		return client, nil
+	}
+
+	errs := make([]error, len(firelens.externalConfigs))
+	sem := make(chan struct{}, externalConfigDownloadConcurrency)
+	var wg sync.WaitGroup
+
+	for i, config := range firelens.externalConfigs {
+		if config.Type != ExternalConfigTypeS3 {
+			continue
+		}
+		i, config := i, config
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = firelens.downloadExternalConfigEntry(i, config, getS3Client)
+		}()
+	}
+	wg.Wait()
+
+	for i, config := range firelens.externalConfigs {
+		if config.Type == ExternalConfigTypeS3 {
+			continue
+		}
+		errs[i] = firelens.downloadExternalConfigEntry(i, config, getS3Client)
+	}
+
+	for i, err := range errs {
+		if err != nil {
+			return errors.Wrapf(err, "unable to download external config entry %d (type %s, value %s)",
+				i, firelens.externalConfigs[i].Type, firelens.externalConfigs[i].Value)
+		}
+	}
+	return nil
+}
+
+// downloadExternalConfigEntry downloads a single externalConfigs entry to its external_<n>.conf destination.
+func (firelens *FirelensResource) downloadExternalConfigEntry(index int, config ExternalConfig,
+	getS3Client func(bucket string) (s3manageriface.DownloaderAPI, error)) error {
+	destPath := firelens.externalConfigFilePathN(index)
+
+	switch config.Type {
+	case ExternalConfigTypeS3:
+		bucket, key, err := s3.ParseS3ARN(config.Value)
+		if err != nil {
+			return errors.Wrap(err, "unable to parse bucket and key from s3 arn")
+		}
+		s3Client, err := getS3Client(bucket)
+		if err != nil {
+			return errors.Wrapf(err, "unable to initialize s3 client for bucket %s", bucket)
+		}
+		return firelens.writeConfigFile(func(file oswrapper.File) error {
+			return s3.DownloadFile(bucket, key, s3DownloadTimeout, file, s3Client)
+		}, destPath)
+	case ExternalConfigTypeHTTPS:
+		return firelens.downloadHTTPSConfigTo(config.Value, destPath)
+	case ExternalConfigTypeSSM:
+		return firelens.downloadSSMConfigTo(config.Value, destPath)
+	case ExternalConfigTypeSecretsManager:
+		return firelens.downloadSecretsManagerConfigTo(config.Value, destPath)
+	default:
+		return errors.Errorf("unsupported external config type %s", config.Type)
+	}
+}
+
 var rename = os.Rename
 
 // writeConfigFile writes a config file at a given path.
@@ -543,6 +1239,84 @@ func (firelens *FirelensResource) writeConfigFile(writeFunc func(file oswrapper.
 	return nil
 }
 
+// hashingFile wraps an oswrapper.File, feeding the bytes passed to Write through a hash.Hash so their digest can be
+// inspected once the write completes. WriteAt calls, which the s3 downloader uses, reach the wrapped file unhashed.
+type hashingFile struct {
+	oswrapper.File
+	hash hash.Hash
+}
+
+func (h *hashingFile) Write(p []byte) (int, error) {
+	h.hash.Write(p)
+	return h.File.Write(p)
+}
+
+var removeFile = os.Remove
+
+// sha256MismatchError indicates that a downloaded config's digest didn't match the value configured via
+// config-file-sha256. It is always non-retryable: the bytes fetched are wrong or tampered with, and retrying an
+// unchanged S3 object will not produce a different digest.
+type sha256MismatchError struct {
+	expected string
+	actual   string
+}
+
+func (e *sha256MismatchError) Error() string {
+	return fmt.Sprintf("sha256 mismatch: expected %s but got %s", e.expected, e.actual)
+}
+
+// writeConfigFileVerified writes a config file at a given path the same way writeConfigFile does, but also computes
+// a sha256 digest of the bytes written. If expectedSHA256 is non-empty, the digest is checked against it before the
+// temp file is renamed into place; on a mismatch, the temp file is discarded and an error is returned instead.
+func (firelens *FirelensResource) writeConfigFileVerified(writeFunc func(file oswrapper.File) error, filePath, expectedSHA256 string) error {
+	temp, err := firelens.ioutil.TempFile(firelens.resourceDir, tempFile)
+	if err != nil {
+		return err
+	}
+	defer temp.Close()
+
+	err = writeFunc(temp)
+	if err != nil {
+		removeFile(temp.Name())
+		return err
+	}
+
+	if expectedSHA256 != "" {
+		// The s3 downloader writes through WriteAt, in parts that can land out of order, so the digest is taken from
+		// the bytes on disk rather than from the writes themselves.
+		written, err := os.Open(temp.Name())
+		if err != nil {
+			removeFile(temp.Name())
+			return err
+		}
+		digest := sha256.New()
+		_, err = io.Copy(digest, written)
+		written.Close()
+		if err != nil {
+			removeFile(temp.Name())
+			return err
+		}
+		actualSHA256 := hex.EncodeToString(digest.Sum(nil))
+		if !strings.EqualFold(actualSHA256, expectedSHA256) {
+			removeFile(temp.Name())
+			return &sha256MismatchError{expected: expectedSHA256, actual: actualSHA256}
+		}
+	}
+
+	err = temp.Chmod(os.FileMode(configFilePerm))
+	if err != nil {
+		return err
+	}
+
+	// Persist the config file to disk.
+	err = temp.Sync()
+	if err != nil {
+		return err
+	}
+
+	return rename(temp.Name(), filePath)
+}
+
 var removeAll = os.RemoveAll
 
 // Cleanup performs resource cleanup.